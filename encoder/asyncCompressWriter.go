@@ -0,0 +1,107 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package encoder
+
+import (
+	"compress/gzip"
+	"sync"
+)
+
+// asyncCompressQueueSize bounds how many pending records may queue up
+// waiting for the compression goroutine, so a stalled sink applies
+// backpressure instead of growing memory use without limit.
+const asyncCompressQueueSize = 256
+
+// asyncCompressWriter sits in front of a gzip.Writer and moves compression
+// onto a dedicated goroutine, so compression does not run inline with packet
+// decode. Each Write enqueues a copy of its argument (taken from bufferPool)
+// and returns without waiting for it to be compressed; it only blocks while
+// asyncCompressQueueSize records are already pending. The background
+// goroutine drains the queue and performs the actual (CPU-heavy) compression.
+//
+// Because compression happens after Write has already returned success, a
+// failure is necessarily discovered later than the record that caused it -
+// the writeErrorsTotal counter is incremented right here, in run(), the
+// moment the real failure happens, rather than being left to surface (and
+// be mis-attributed to some unrelated later record) through the next Write
+// call's stored-error check.
+type asyncCompressWriter struct {
+	gw     *gzip.Writer
+	queue  chan []byte
+	done   chan struct{}
+	labels [2]string // encoder, type - for the error counter in run()
+
+	mu  sync.Mutex
+	err error
+}
+
+func newAsyncCompressWriter(gw *gzip.Writer, encoderName, typeName string) *asyncCompressWriter {
+	a := &asyncCompressWriter{
+		gw:     gw,
+		queue:  make(chan []byte, asyncCompressQueueSize),
+		done:   make(chan struct{}),
+		labels: [2]string{encoderName, typeName},
+	}
+	go a.run()
+	return a
+}
+
+func (a *asyncCompressWriter) run() {
+	defer close(a.done)
+	for data := range a.queue {
+		if _, err := a.gw.Write(data); err != nil {
+			a.mu.Lock()
+			if a.err == nil {
+				a.err = err
+			}
+			a.mu.Unlock()
+			writeErrorsTotal.WithLabelValues(a.labels[0], a.labels[1]).Inc()
+		}
+		if cap(data) <= maxPooledBufferSize {
+			b := data[:0]
+			bufferPool.Put(&b)
+		}
+	}
+}
+
+// Write queues a copy of p for compression on the background goroutine.
+func (a *asyncCompressWriter) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	err := a.err
+	a.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	bufPtr := bufferPool.Get().(*[]byte)
+	cp := append((*bufPtr)[:0], p...)
+	a.queue <- cp
+
+	return len(p), nil
+}
+
+// Close waits for all queued records to be compressed and then closes the
+// underlying gzip.Writer. Safe to call once, after the last Write.
+func (a *asyncCompressWriter) Close() error {
+	close(a.queue)
+	<-a.done
+
+	a.mu.Lock()
+	err := a.err
+	a.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return a.gw.Close()
+}
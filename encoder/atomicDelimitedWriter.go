@@ -0,0 +1,127 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package encoder
+
+import (
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"kythe.io/kythe/go/platform/delimited"
+)
+
+// maxPooledBufferSize caps the size of a buffer that is returned to
+// bufferPool: a single oversized record shouldn't permanently inflate every
+// buffer future callers draw from the pool.
+const maxPooledBufferSize = 1 << 20 // 1 MiB
+
+// bufferPool holds reusable byte slices for marshaling protobuf records,
+// avoiding a fresh allocation per packet on the encoder hot path.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 4096)
+		return &b
+	},
+}
+
+// writeProto marshals msg and writes it to w, returning the marshaled
+// (pre-compression) length. The caller must already hold whatever lock
+// serializes access to w.
+//
+// When pooled is true, the marshal buffer is drawn from and returned to
+// bufferPool once Put has returned. delimited.Writer hands the slice straight
+// to the writer beneath it, so that's only safe when every writer in the
+// chain (bufio.Writer, asyncCompressWriter, the sink's writer) has consumed
+// or copied the bytes before its Write returns. pooled must be false for a
+// writer chain where that doesn't hold - e.g. the WriteChan transport, whose
+// chanWriter forwards the slice onto a channel for an external consumer, so
+// recycling it the moment Put returns would hand out a buffer that's still
+// in flight.
+func writeProto(w *delimited.Writer, msg proto.Message, pooled bool) (int, error) {
+	if !pooled {
+		data, err := proto.Marshal(msg)
+		if err != nil {
+			return 0, err
+		}
+		return len(data), w.Put(data)
+	}
+
+	bufPtr := bufferPool.Get().(*[]byte)
+
+	pb := proto.NewBuffer((*bufPtr)[:0])
+	if err := pb.Marshal(msg); err != nil {
+		bufferPool.Put(bufPtr)
+		return 0, err
+	}
+	data := pb.Bytes()
+	n := len(data)
+
+	err := w.Put(data)
+
+	if cap(data) <= maxPooledBufferSize {
+		*bufPtr = data[:0]
+		bufferPool.Put(bufPtr)
+	}
+
+	return n, err
+}
+
+// AtomicDelimitedWriter serializes concurrent writes of length-delimited
+// protobuf records onto a single underlying stream.
+type AtomicDelimitedWriter struct {
+	mu     sync.Mutex
+	w      *delimited.Writer
+	pooled bool
+}
+
+// NewAtomicDelimitedWriter returns a new AtomicDelimitedWriter wrapping w.
+// pooled selects whether marshal buffers are drawn from bufferPool; pass
+// false when w's downstream consumer doesn't copy the slice it's handed
+// before PutProto returns (see writeProto).
+func NewAtomicDelimitedWriter(w *delimited.Writer, pooled bool) *AtomicDelimitedWriter {
+	return &AtomicDelimitedWriter{w: w, pooled: pooled}
+}
+
+// PutProto marshals msg and writes it to the underlying delimited.Writer
+// under the lock, returning the marshaled (pre-compression) length.
+//
+// If rotate is non-nil, it runs while the lock is still held, immediately
+// after a successful write, and is passed that same length: this lets
+// CustomEncoder account the record against its (pre-compression)
+// RotatePolicy.MaxBytes threshold and, if it's been crossed, swap in the
+// next segment's delimited.Writer atomically with respect to every other
+// PutProto call - no goroutine can write into a half-closed segment, and
+// two goroutines can never both decide to rotate at once. rotate returns a
+// non-nil *delimited.Writer to install as the new target, or (nil, nil)
+// when no rotation was needed.
+func (a *AtomicDelimitedWriter) PutProto(msg proto.Message, rotate func(n int) (*delimited.Writer, error)) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	n, err := writeProto(a.w, msg, a.pooled)
+	if err != nil {
+		return n, err
+	}
+
+	if rotate != nil {
+		next, rerr := rotate(n)
+		if rerr != nil {
+			return n, rerr
+		}
+		if next != nil {
+			a.w = next
+		}
+	}
+
+	return n, nil
+}
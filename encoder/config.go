@@ -0,0 +1,80 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package encoder
+
+import "time"
+
+// Config allows to configure the encoder package.
+type Config struct {
+	Buffer          bool
+	Compression     bool
+	CSV             bool
+	Out             string
+	WriteChan       bool
+	IncludeEncoders string
+	ExcludeEncoders string
+
+	// SinkURL selects the backend used to persist encoder output.
+	// Supported schemes: file:// (default, local filesystem), s3://, swift://, azblob://
+	// e.g. "s3://bucket/prefix?region=eu-central-1"
+	SinkURL string
+
+	// Rotate configures size-/time-/count-based rotation of encoder output
+	// segments. The zero value disables rotation.
+	Rotate RotatePolicy
+
+	// MetricsAddr, if set, starts a Prometheus HTTP exporter on this address
+	// (e.g. ":7777") exposing per-encoder counters under /metrics.
+	MetricsAddr string
+
+	// TracingEnabled turns reconstructed flows and HTTP transactions into
+	// distributed tracing spans, see package tracer.
+	TracingEnabled bool
+
+	// CollectorURL is the tracing collector spans are exported to, e.g.
+	// "http://localhost:9411/api/v2/spans" (Zipkin) or "localhost:4317" (OTLP).
+	CollectorURL string
+
+	// ServiceName identifies the synthetic service traced spans are
+	// reported under. Defaults to "netcap" when empty.
+	ServiceName string
+
+	// SamplerRate is the fraction of spans (0.0-1.0) that are exported.
+	SamplerRate float64
+}
+
+// RotatePolicy configures when a CustomEncoder closes its current output
+// segment and opens a new one. A zero field in the policy disables that
+// particular trigger; a zero RotatePolicy disables rotation entirely.
+//
+// Rotation only applies to the length-delimited protobuf output format;
+// Config.CSV output is not rotated, regardless of this policy.
+type RotatePolicy struct {
+	// MaxBytes rotates once the current segment has written at least this
+	// many bytes (measured before compression).
+	MaxBytes int64
+
+	// MaxDuration rotates once the current segment has been open for at
+	// least this long.
+	MaxDuration time.Duration
+
+	// MaxRecords rotates once the current segment has received at least
+	// this many audit records.
+	MaxRecords int64
+}
+
+// enabled reports whether any rotation trigger is configured.
+func (p RotatePolicy) enabled() bool {
+	return p.MaxBytes > 0 || p.MaxDuration > 0 || p.MaxRecords > 0
+}
@@ -0,0 +1,112 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package encoder
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsLabels are the label names shared by all encoder collectors:
+// the encoder name (e.g. "HTTP") and its netcap audit record type.
+var metricsLabels = []string{"encoder", "type"}
+
+var (
+	metricsOnce sync.Once
+
+	recordsWrittenTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "netcap",
+		Subsystem: "encoder",
+		Name:      "records_written_total",
+		Help:      "Number of audit records written by a custom encoder.",
+	}, metricsLabels)
+
+	bytesWrittenRawTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "netcap",
+		Subsystem: "encoder",
+		Name:      "bytes_written_raw_total",
+		Help:      "Bytes written by a custom encoder before compression.",
+	}, metricsLabels)
+
+	bytesWrittenCompressedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "netcap",
+		Subsystem: "encoder",
+		Name:      "bytes_written_compressed_total",
+		Help:      "Bytes written by a custom encoder after compression.",
+	}, metricsLabels)
+
+	writeErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "netcap",
+		Subsystem: "encoder",
+		Name:      "write_errors_total",
+		Help:      "Errors encountered while writing audit records.",
+	}, metricsLabels)
+
+	handlerDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "netcap",
+		Subsystem: "encoder",
+		Name:      "handler_duration_seconds",
+		Help:      "Time spent inside a custom encoder's Handler function.",
+		Buckets:   prometheus.DefBuckets,
+	}, metricsLabels)
+
+	currentSegmentNumber = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "netcap",
+		Subsystem: "encoder",
+		Name:      "current_segment",
+		Help:      "Index of the output segment a custom encoder is currently writing to.",
+	}, metricsLabels)
+)
+
+// registerMetrics registers all encoder collectors with the default
+// Prometheus registry and starts serving them on addr under /metrics.
+// It is safe to call more than once; only the first call takes effect.
+func registerMetrics(addr string) {
+	metricsOnce.Do(func() {
+		prometheus.MustRegister(
+			recordsWrittenTotal,
+			bytesWrittenRawTotal,
+			bytesWrittenCompressedTotal,
+			writeErrorsTotal,
+			handlerDurationSeconds,
+			currentSegmentNumber,
+		)
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+
+		go func() {
+			if err := http.ListenAndServe(addr, mux); err != nil {
+				fmt.Println("metrics server stopped:", err)
+			}
+		}()
+	})
+}
+
+// deregisterMetrics drops the label series belonging to a single encoder
+// instance. Called from CustomEncoder.Destroy so metrics don't keep
+// reporting stale values for an encoder that no longer exists.
+func deregisterMetrics(name, typ string) {
+	labels := prometheus.Labels{"encoder": name, "type": typ}
+	recordsWrittenTotal.Delete(labels)
+	bytesWrittenRawTotal.Delete(labels)
+	bytesWrittenCompressedTotal.Delete(labels)
+	writeErrorsTotal.Delete(labels)
+	handlerDurationSeconds.Delete(labels)
+	currentSegmentNumber.Delete(labels)
+}
@@ -0,0 +1,63 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package encoder
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// Sink abstracts the destination encoders write their output segments to.
+// Implementations back local disk as well as remote object storage, so a
+// capture doesn't have to fit on the machine it runs on.
+type Sink interface {
+	// NewWriter opens a new output segment named "name" with the given
+	// extension (e.g. ".ncap.gz") and returns a writer for it. Callers are
+	// expected to call Close on the returned writer once the segment is
+	// complete.
+	NewWriter(name, ext string) (io.WriteCloser, error)
+
+	// Finalize flushes and commits any outstanding state held by the sink,
+	// e.g. completing a pending multipart upload. It is called once, after
+	// all segments have been written and closed.
+	Finalize() error
+}
+
+// NewSink parses rawURL and returns the matching Sink implementation.
+// An empty rawURL falls back to the local filesystem sink rooted at out,
+// which preserves the previous CreateFile-based behavior.
+func NewSink(rawURL, out string) (Sink, error) {
+	if rawURL == "" {
+		return newFileSink(out), nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sink url %q: %w", rawURL, err)
+	}
+
+	switch u.Scheme {
+	case "", "file":
+		return newFileSink(u.Path), nil
+	case "s3":
+		return newS3Sink(u)
+	case "swift":
+		return newSwiftSink(u)
+	case "azblob":
+		return newAzureSink(u)
+	default:
+		return nil, fmt.Errorf("unsupported sink scheme: %q", u.Scheme)
+	}
+}
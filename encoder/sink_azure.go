@@ -0,0 +1,90 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package encoder
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// azureSink persists encoder output segments as block blobs in an Azure
+// Blob Storage container, streaming each segment via azblob's staged-block
+// upload so it never has to be buffered locally. The URL shape is
+// azblob://container/prefix. Credentials are read from the standard
+// AZURE_STORAGE_ACCOUNT / AZURE_STORAGE_ACCESS_KEY environment variables.
+type azureSink struct {
+	containerURL azblob.ContainerURL
+	prefix       string
+}
+
+func newAzureSink(u *url.URL) (*azureSink, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("azure sink url %q is missing a container name", u.String())
+	}
+
+	account := os.Getenv("AZURE_STORAGE_ACCOUNT")
+	key := os.Getenv("AZURE_STORAGE_ACCESS_KEY")
+
+	cred, err := azblob.NewSharedKeyCredential(account, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create azure shared key credential: %w", err)
+	}
+
+	pipeline := azblob.NewPipeline(cred, azblob.PipelineOptions{})
+	serviceURL, err := url.Parse(fmt.Sprintf("https://%s.blob.core.windows.net", account))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build azure service url: %w", err)
+	}
+
+	containerURL := azblob.NewServiceURL(*serviceURL, pipeline).NewContainerURL(u.Host)
+
+	return &azureSink{
+		containerURL: containerURL,
+		prefix:       strings.Trim(u.Path, "/"),
+	}, nil
+}
+
+func (s *azureSink) blobName(name, ext string) string {
+	blob := name + ext
+	if s.prefix != "" {
+		blob = s.prefix + "/" + blob
+	}
+	return blob
+}
+
+func (s *azureSink) NewWriter(name, ext string) (io.WriteCloser, error) {
+	blobURL := s.containerURL.NewBlockBlobURL(s.blobName(name, ext))
+
+	return newPipeUpload(func(r io.Reader) error {
+		_, err := azblob.UploadStreamToBlockBlob(context.Background(), r, blobURL, azblob.UploadStreamToBlockBlobOptions{
+			BufferSize: BlockSize,
+			MaxBuffers: 4,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upload %s to azure container: %w", s.blobName(name, ext), err)
+		}
+		return nil
+	}), nil
+}
+
+// Finalize is a no-op: every segment commits its own block list on Close.
+func (s *azureSink) Finalize() error {
+	return nil
+}
@@ -0,0 +1,39 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package encoder
+
+import (
+	"io"
+	"path/filepath"
+)
+
+// fileSink writes encoder output segments to the local filesystem.
+// It is the default sink and reproduces the historic CreateFile behavior.
+type fileSink struct {
+	out string
+}
+
+func newFileSink(out string) *fileSink {
+	return &fileSink{out: out}
+}
+
+func (s *fileSink) NewWriter(name, ext string) (io.WriteCloser, error) {
+	return CreateFile(filepath.Join(s.out, name), ext), nil
+}
+
+// Finalize is a no-op for the local filesystem sink: segments are already
+// durable once closed.
+func (s *fileSink) Finalize() error {
+	return nil
+}
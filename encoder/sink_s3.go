@@ -0,0 +1,86 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package encoder
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// s3Sink persists encoder output segments as objects in an Amazon S3 bucket,
+// uploading each segment as a multipart upload so it never has to be
+// buffered on local disk. The URL shape is s3://bucket/prefix?region=...
+type s3Sink struct {
+	bucket   string
+	prefix   string
+	uploader *s3manager.Uploader
+}
+
+func newS3Sink(u *url.URL) (*s3Sink, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("s3 sink url %q is missing a bucket name", u.String())
+	}
+
+	region := u.Query().Get("region")
+	if region == "" {
+		return nil, fmt.Errorf("s3 sink url %q is missing the region query parameter", u.String())
+	}
+
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 session: %w", err)
+	}
+
+	return &s3Sink{
+		bucket:   u.Host,
+		prefix:   strings.Trim(u.Path, "/"),
+		uploader: s3manager.NewUploader(sess),
+	}, nil
+}
+
+func (s *s3Sink) key(name, ext string) string {
+	key := name + ext
+	if s.prefix != "" {
+		key = s.prefix + "/" + key
+	}
+	return key
+}
+
+func (s *s3Sink) NewWriter(name, ext string) (io.WriteCloser, error) {
+	key := s.key(name, ext)
+
+	return newPipeUpload(func(r io.Reader) error {
+		_, err := s.uploader.Upload(&s3manager.UploadInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(key),
+			Body:   r,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upload %s to s3://%s: %w", key, s.bucket, err)
+		}
+		return nil
+	}), nil
+}
+
+// Finalize is a no-op: every segment completes its own multipart upload on
+// Close, there is nothing left outstanding at the sink level.
+func (s *s3Sink) Finalize() error {
+	return nil
+}
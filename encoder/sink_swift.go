@@ -0,0 +1,88 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package encoder
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/ncw/swift"
+)
+
+// swiftSink persists encoder output segments as objects in an OpenStack
+// Swift container, using Swift's large-object support (SLO) so segments
+// stream to the object store instead of being buffered locally. The URL
+// shape is swift://container/prefix. Connection details are read from the
+// standard ST_AUTH / ST_USER / ST_KEY environment variables used by the
+// swift CLI.
+type swiftSink struct {
+	container string
+	prefix    string
+	conn      *swift.Connection
+}
+
+func newSwiftSink(u *url.URL) (*swiftSink, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("swift sink url %q is missing a container name", u.String())
+	}
+
+	conn := &swift.Connection{
+		AuthUrl:  os.Getenv("ST_AUTH"),
+		UserName: os.Getenv("ST_USER"),
+		ApiKey:   os.Getenv("ST_KEY"),
+	}
+
+	if err := conn.Authenticate(); err != nil {
+		return nil, fmt.Errorf("failed to authenticate against swift: %w", err)
+	}
+
+	return &swiftSink{
+		container: u.Host,
+		prefix:    strings.Trim(u.Path, "/"),
+		conn:      conn,
+	}, nil
+}
+
+func (s *swiftSink) objectName(name, ext string) string {
+	object := name + ext
+	if s.prefix != "" {
+		object = s.prefix + "/" + object
+	}
+	return object
+}
+
+// NewWriter opens a segmented large-object upload and returns its writer
+// directly: the swift client already streams writes as object segments,
+// committing the manifest when the writer is closed.
+func (s *swiftSink) NewWriter(name, ext string) (io.WriteCloser, error) {
+	object := s.objectName(name, ext)
+
+	w, err := s.conn.LargeObjectCreateFile(&swift.LargeObjectOpts{
+		Container:  s.container,
+		ObjectName: object,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start large object upload for %s to swift://%s: %w", object, s.container, err)
+	}
+	return w, nil
+}
+
+// Finalize is a no-op: every segment commits its own large-object manifest
+// on Close.
+func (s *swiftSink) Finalize() error {
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package encoder
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// pipeUpload feeds bytes written to it into an io.Reader consumed by a
+// background upload goroutine, so object-storage sinks can stream segments
+// as multipart uploads instead of buffering them in memory. Close blocks
+// until the upload goroutine has confirmed the object is committed.
+type pipeUpload struct {
+	pr   *io.PipeReader
+	pw   *io.PipeWriter
+	done chan error
+}
+
+// newPipeUpload starts upload in a background goroutine, wiring its reader
+// to data written through the returned *pipeUpload.
+func newPipeUpload(upload func(r io.Reader) error) *pipeUpload {
+	pr, pw := io.Pipe()
+
+	u := &pipeUpload{
+		pr:   pr,
+		pw:   pw,
+		done: make(chan error, 1),
+	}
+
+	go func() {
+		err := upload(pr)
+		// If upload returns early (auth failure, dropped connection) without
+		// draining pr, every future pw.Write would block forever waiting for
+		// a reader that's gone - and that Write runs under
+		// AtomicDelimitedWriter's lock inside Encode, so the deadlock would
+		// take the whole encoder down with it. Closing pr with the error
+		// unblocks any in-flight or future Write with that same error instead.
+		_ = pr.CloseWithError(err)
+		u.done <- err
+	}()
+
+	return u
+}
+
+func (u *pipeUpload) Write(p []byte) (int, error) {
+	return u.pw.Write(p)
+}
+
+// Close signals EOF to the upload goroutine and waits for the object to be
+// committed remotely.
+func (u *pipeUpload) Close() error {
+	if err := u.pw.Close(); err != nil {
+		return err
+	}
+	return <-u.done
+}
+
+// countingWriteCloser wraps an io.WriteCloser from a Sink and tracks the
+// number of bytes written to it, so Destroy can report segment size
+// regardless of which backend produced the underlying writer.
+type countingWriteCloser struct {
+	io.WriteCloser
+	n int64
+}
+
+func (c *countingWriteCloser) Write(p []byte) (int, error) {
+	n, err := c.WriteCloser.Write(p)
+	atomic.AddInt64(&c.n, int64(n))
+	return n, err
+}
+
+func (c *countingWriteCloser) Len() int64 {
+	return atomic.LoadInt64(&c.n)
+}
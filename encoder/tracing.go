@@ -0,0 +1,75 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package encoder
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dreadl0ck/netcap/tracer"
+)
+
+// activeTracer is the single Tracer that EmitSpan reports to, shared by all
+// encoders. No encoder's deinit/finalize path in this package calls EmitSpan,
+// so no flow or HTTP spans are produced unless another caller invokes it.
+var activeTracer tracer.Tracer
+
+// closeTracingOnce guards the shared tracer's shutdown so a stray extra
+// call to CloseTracing (e.g. a caller destroying encoders one by one instead
+// of through DestroyCustomEncoders) can't close it twice.
+var closeTracingOnce sync.Once
+
+// initTracing sets up the shared tracer if c.TracingEnabled is set.
+func initTracing(c Config) error {
+	if !c.TracingEnabled {
+		return nil
+	}
+
+	t, err := tracer.NewFromConfig(tracer.Config{
+		CollectorURL: c.CollectorURL,
+		ServiceName:  c.ServiceName,
+		SamplerRate:  c.SamplerRate,
+	})
+	if err != nil {
+		return err
+	}
+	activeTracer = t
+	return nil
+}
+
+// EmitSpan reports a completed flow or HTTP transaction as a tracing span.
+// It is intended to be called from an encoder's deinit/finalize path (e.g.
+// flowEncoder, connectionEncoder, httpEncoder) once a request/response pair
+// or flow lifetime has been fully reconstructed. It is a no-op unless
+// tracing was enabled via Config.TracingEnabled.
+func EmitSpan(operation string, start, end time.Time, tags map[string]string) {
+	if activeTracer == nil {
+		return
+	}
+	activeTracer.Span(operation, start, end, tags)
+}
+
+// CloseTracing flushes and shuts down the shared tracer. It must be called
+// exactly once, after every encoder has been destroyed and had a chance to
+// emit its spans - see DestroyCustomEncoders, which is the right place to
+// call it from rather than CustomEncoder.Destroy.
+func CloseTracing() error {
+	var err error
+	closeTracingOnce.Do(func() {
+		if activeTracer != nil {
+			err = activeTracer.Close()
+		}
+	})
+	return err
+}
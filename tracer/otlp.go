@@ -0,0 +1,85 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tracer
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otlpTracer exports spans via OTLP/gRPC using a single in-process
+// TracerProvider, batching spans to cfg.CollectorURL.
+type otlpTracer struct {
+	cfg      Config
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+}
+
+func newOTLPTracer(cfg Config) (*otlpTracer, error) {
+	ctx := context.Background()
+
+	exp, err := otlptracegrpc.New(ctx,
+		otlptracegrpc.WithEndpoint(cfg.CollectorURL),
+		otlptracegrpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(
+		semconv.ServiceNameKey.String(cfg.serviceName()),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+	)
+
+	return &otlpTracer{
+		cfg:      cfg,
+		provider: provider,
+		tracer:   provider.Tracer("github.com/dreadl0ck/netcap/tracer"),
+	}, nil
+}
+
+func (t *otlpTracer) Span(operation string, start, end time.Time, tags map[string]string) {
+	if !t.cfg.sample() {
+		return
+	}
+
+	attrs := make([]attribute.KeyValue, 0, len(tags))
+	for k, v := range tags {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	_, span := t.tracer.Start(context.Background(), operation,
+		trace.WithTimestamp(start),
+		trace.WithAttributes(attrs...),
+	)
+	span.End(trace.WithTimestamp(end))
+}
+
+func (t *otlpTracer) Close() error {
+	return t.provider.Shutdown(context.Background())
+}
@@ -0,0 +1,109 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+// Package tracer turns reconstructed netcap flows and HTTP transactions
+// into distributed tracing spans, so a PCAP replay can be explored in any
+// tracing backend that accepts Zipkin v2 or OTLP.
+package tracer
+
+import (
+	"math/rand"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Config configures a Tracer. It mirrors the tracing fields exposed on
+// encoder.Config (CollectorURL, ServiceName, SamplerRate); it has no
+// TracingEnabled field, since that setting decides whether a Tracer is
+// created at all.
+type Config struct {
+	// CollectorURL is the endpoint spans are exported to, e.g.
+	// "http://localhost:9411/api/v2/spans" for Zipkin or
+	// "localhost:4317" for OTLP/gRPC.
+	CollectorURL string
+
+	// ServiceName identifies the synthetic service all spans are reported
+	// under, defaulting to "netcap" when empty.
+	ServiceName string
+
+	// SamplerRate is the fraction of spans (0.0-1.0) that are actually
+	// exported. A rate <= 0 is treated as 1 (sample everything).
+	SamplerRate float64
+}
+
+func (c Config) serviceName() string {
+	if c.ServiceName == "" {
+		return "netcap"
+	}
+	return c.ServiceName
+}
+
+func (c Config) sample() bool {
+	if c.SamplerRate <= 0 || c.SamplerRate >= 1 {
+		return true
+	}
+	return rand.Float64() < c.SamplerRate
+}
+
+// Tracer reports completed flows and HTTP transactions as tracing spans.
+type Tracer interface {
+	// Span reports a single completed operation (e.g. "tcp", "udp",
+	// "http") with its lifetime and string tags.
+	Span(operation string, start, end time.Time, tags map[string]string)
+
+	// Close flushes any buffered spans and releases the exporter's
+	// resources. It is called once during shutdown.
+	Close() error
+}
+
+// Kind selects which wire format a Tracer exports spans in.
+type Kind string
+
+const (
+	// Zipkin exports spans as Zipkin v2 JSON over HTTP.
+	Zipkin Kind = "zipkin"
+
+	// OTLP exports spans via OTLP/gRPC.
+	OTLP Kind = "otlp"
+)
+
+// New creates a Tracer of the given kind.
+func New(kind Kind, cfg Config) (Tracer, error) {
+	switch kind {
+	case Zipkin, "":
+		return newZipkinTracer(cfg)
+	case OTLP:
+		return newOTLPTracer(cfg)
+	default:
+		return nil, &unsupportedKindError{kind}
+	}
+}
+
+// NewFromConfig picks the export format from the shape of cfg.CollectorURL:
+// an http(s):// URL is treated as a Zipkin v2 HTTP collector, anything else
+// (a bare host:port, as used for gRPC targets) is treated as OTLP/gRPC.
+func NewFromConfig(cfg Config) (Tracer, error) {
+	if u, err := url.Parse(cfg.CollectorURL); err == nil && strings.HasPrefix(u.Scheme, "http") {
+		return New(Zipkin, cfg)
+	}
+	return New(OTLP, cfg)
+}
+
+type unsupportedKindError struct {
+	kind Kind
+}
+
+func (e *unsupportedKindError) Error() string {
+	return "tracer: unsupported kind: " + string(e.kind)
+}
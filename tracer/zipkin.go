@@ -0,0 +1,70 @@
+/*
+ * NETCAP - Traffic Analysis Framework
+ * Copyright (c) 2017 Philipp Mieden <dreadl0ck [at] protonmail [dot] ch>
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tracer
+
+import (
+	"time"
+
+	"github.com/openzipkin/zipkin-go/idgenerator"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/reporter"
+	zipkinhttp "github.com/openzipkin/zipkin-go/reporter/http"
+)
+
+// zipkinTracer exports spans as Zipkin v2 JSON over HTTP. Each reconstructed
+// flow or HTTP transaction becomes a single root span, since netcap replays
+// observed traffic rather than participating in a live trace.
+type zipkinTracer struct {
+	cfg      Config
+	reporter reporter.Reporter
+	gen      idgenerator.IDGenerator
+	endpoint *model.Endpoint
+}
+
+func newZipkinTracer(cfg Config) (*zipkinTracer, error) {
+	ep, err := model.NewEndpoint(cfg.serviceName(), "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &zipkinTracer{
+		cfg:      cfg,
+		reporter: zipkinhttp.NewReporter(cfg.CollectorURL),
+		gen:      idgenerator.NewRandom64(),
+		endpoint: ep,
+	}, nil
+}
+
+func (t *zipkinTracer) Span(operation string, start, end time.Time, tags map[string]string) {
+	if !t.cfg.sample() {
+		return
+	}
+
+	t.reporter.Send(model.SpanModel{
+		SpanContext: model.SpanContext{
+			TraceID: t.gen.TraceID(),
+			ID:      t.gen.SpanID(model.TraceID{}),
+		},
+		Name:          operation,
+		Kind:          model.Client,
+		Timestamp:     start,
+		Duration:      end.Sub(start),
+		LocalEndpoint: t.endpoint,
+		Tags:          tags,
+	})
+}
+
+func (t *zipkinTracer) Close() error {
+	return t.reporter.Close()
+}